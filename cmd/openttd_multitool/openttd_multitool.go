@@ -3,10 +3,18 @@ package main
 import (
 	"flag"
 	"github.com/tardisx/openttd-admin/pkg/admin"
+	"github.com/tardisx/openttd-admin/pkg/bridge"
 	"os"
 	"strings"
 )
 
+// these match OpenTTD's NetworkAction/DestType enums for a plain broadcast
+// chat message - see src/network/network_type.h in the OpenTTD source.
+const (
+	chatActionChat        = 3
+	chatDestTypeBroadcast = 0
+)
+
 const currentVersion = "0.02"
 
 type dailyFlags []string
@@ -56,9 +64,17 @@ func main() {
 	var hostname string
 	var password string
 	var port int
+	var telegramToken string
+	var telegramChat string
+	var webhookURL string
+	var chatPrefix string
 	flag.StringVar(&hostname, "hostname", "localhost", "The hostname (or IP address) of the OpenTTD server to connect to")
 	flag.StringVar(&password, "password", "", "The password for the admin interface ('admin_password' in openttd.cfg)")
 	flag.IntVar(&port, "port", 3977, "The port number of the admin interface (default is 3977)")
+	flag.StringVar(&telegramToken, "telegram-token", "", "Telegram bot token, to bridge chat via Telegram")
+	flag.StringVar(&telegramChat, "telegram-chat", "", "Telegram chat ID to bridge chat with")
+	flag.StringVar(&webhookURL, "webhook-url", "", "Webhook URL to forward in-game chat to")
+	flag.StringVar(&chatPrefix, "chat-prefix", "[ext]", "Prefix added to messages relayed from the bridge into the game")
 	flag.Parse()
 
 	if password == "" {
@@ -80,6 +96,28 @@ func main() {
 		server.RegisterDateChange("yearly", value)
 	}
 
+	var bridges []bridge.Bridge
+	if telegramToken != "" {
+		bridges = append(bridges, bridge.NewTelegramBridge(telegramToken, telegramChat))
+	}
+	if webhookURL != "" {
+		bridges = append(bridges, bridge.NewWebhookBridge(webhookURL))
+	}
+
+	for _, b := range bridges {
+		b := b
+		server.OnChat(func(evt admin.ChatEvent) {
+			if err := b.Send(evt.Message); err != nil {
+				println("bridge send error:", err.Error())
+			}
+		})
+		go func() {
+			for msg := range b.Incoming() {
+				server.SendChat(chatActionChat, chatDestTypeBroadcast, 0, chatPrefix+" "+msg)
+			}
+		}()
+	}
+
 	// this blocks forever
 	server.Connect(hostname, port, password, "openttd-multitool", currentVersion)
 }
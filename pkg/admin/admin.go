@@ -10,10 +10,13 @@ package admin
 
 import (
 	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -34,6 +37,35 @@ type OpenTTDServer struct {
 	rconYearly   []string
 	connected    chan bool
 	disconnected chan bool
+
+	state *state
+
+	eventsMu    sync.Mutex
+	subscribers map[string][]*subscriber
+
+	connState connState
+	// PingInterval is how often the connection watchdog pings the server.
+	// Defaults to defaultPingInterval if unset.
+	PingInterval time.Duration
+
+	updateFrequenciesMu sync.Mutex
+	updateFrequencies   []PacketAdminUpdateFrequency
+
+	// rconMu serializes Rcon calls, since the admin protocol has no way
+	// to correlate an RCON reply with the request that produced it.
+	rconMu sync.Mutex
+
+	// done is closed once Connect's loop stops for good; see Close, Done
+	// and Err.
+	done     chan struct{}
+	doneOnce sync.Once
+
+	// pingOnce guards the one-time registration of the PONG dispatcher
+	// that Ping uses to match replies against pending tokens.
+	pingOnce  sync.Once
+	pendingMu sync.Mutex
+	pending   map[uint32]chan time.Duration
+	pingToken uint32
 }
 
 const (
@@ -103,18 +135,30 @@ const (
 // This method will block, and automatically attempt to reconnect if disconnected.
 func (server *OpenTTDServer) Connect(host string, port int, password string, botName string, botVersion string) {
 
+	if server.state == nil {
+		server.state = newState()
+	}
+	if server.done == nil {
+		server.done = make(chan struct{})
+	}
+
+	backoff := time.Second
 	for {
 
-		// fmt.Printf("array: %v (%T) %d\n", toSend, toSend, size)
 		log.Println("connecting...")
 		connectString := fmt.Sprintf("%s:%d", host, port)
 		conn, err := net.Dial("tcp", connectString)
 		if err != nil {
-			log.Printf("error connecting: %v\n", err)
-			time.Sleep(time.Second * 2)
+			log.Printf("error connecting: %v, retrying in %v\n", err, backoff)
+			if server.shouldStop() {
+				server.stop()
+				return
+			}
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
 			continue
-			//panic(err)
 		}
+		backoff = time.Second
 
 		log.Println("connected")
 
@@ -126,48 +170,58 @@ func (server *OpenTTDServer) Connect(host string, port int, password string, bot
 		server.connection = conn
 		server.connected <- true
 
-		// start listening
-
 		// login
-		var toSend []byte
-		toSend = append(toSend[:], adminPacketAdminJOIN) // type
-		toSend = append(toSend[:], []byte(password)...)  // password
-		toSend = append(toSend[:], 0x0)
-		toSend = append(toSend[:], []byte(botName)...) // client name
-		toSend = append(toSend[:], 0x0)
-		toSend = append(toSend[:], []byte(botVersion)...) // version
-		toSend = append(toSend[:], 0x0)
-		size := len(toSend) + 2
-
-		toSend = append([]byte{byte(size), 0x0}, toSend[:]...)
-		server.connection.Write(toSend)
+		join := PacketAdminJoin{
+			Password:      password,
+			ClientName:    botName,
+			ClientVersion: botVersion,
+		}
+		server.connection.Write(join.Bytes())
 
 		// register for daily updates
-		updateDateCmd := make([]byte, 2)
-		binary.LittleEndian.PutUint16(updateDateCmd, adminUpdateDATE)
-		updateDateDaily := make([]byte, 2)
-		binary.LittleEndian.PutUint16(updateDateDaily, adminFrequencyDAILY)
-
-		toSend = []byte{}
-		toSend = append(toSend, updateDateCmd...)
-		toSend = append(toSend, updateDateDaily...)
-		server.sendSocket(adminPacketAdminUPDATE_FREQUENCY, toSend)
-
-		// toSend = []byte{}
-		// toSend = append(toSend[:], adminPacketAdminUPDATE_FREQUENCY)
-		// toSend = append(toSend[:], adminUpdateCHAT, 0x0)
-		// toSend = append(toSend[:], adminFrequencyAUTOMATIC, 0x0)
-
-		// size = len(toSend) + 2
-		//
-		// toSend = append([]byte{byte(size), 0x0}, toSend[:]...)
-		// fmt.Printf("array: %v (%T) %d\n", toSend, toSend, size)
-		// conn.Write(toSend)
+		freq := PacketAdminUpdateFrequency{
+			Update:    adminUpdateDATE,
+			Frequency: adminFrequencyDAILY,
+		}
+		server.connection.Write(freq.Bytes())
+
+		consoleFreq := PacketAdminUpdateFrequency{
+			Update:    adminUpdateCONSOLE,
+			Frequency: adminFrequencyAUTOMATIC,
+		}
+		server.connection.Write(consoleFreq.Bytes())
+
+		// re-subscribe to anything the caller registered via
+		// SetUpdateFrequency on a previous connection
+		server.updateFrequenciesMu.Lock()
+		for _, f := range server.updateFrequencies {
+			server.connection.Write(f.Bytes())
+		}
+		server.updateFrequenciesMu.Unlock()
+
+		// poll for the full client/company list so the state tracker has a
+		// hot snapshot rather than waiting for the next incremental update
+		server.PollClientInfo()
+		server.PollCompanyInfo()
+
+		server.setConnected(true)
+
+		watchdogDone := make(chan struct{})
+		go server.pingWatchdog(watchdogDone)
 
 		// wait until we are told we disconnected
 		<-server.disconnected
-		fmt.Printf("Reconnecting....")
-		time.Sleep(2 * time.Second)
+		close(watchdogDone)
+		server.setConnected(false)
+
+		if server.shouldStop() {
+			server.stop()
+			return
+		}
+
+		log.Printf("disconnected, reconnecting in %v\n", backoff)
+		time.Sleep(backoff)
+		backoff = nextBackoff(backoff)
 	}
 
 }
@@ -191,6 +245,13 @@ func (server *OpenTTDServer) RegisterDateChange(period string, command string) {
 }
 
 func (server *OpenTTDServer) dateChanged(dt time.Time) {
+	// Serialize against Rcon/RconStream/SendRcon, same as rconCommand's
+	// other callers - without this, the commands fired here could have
+	// their replies folded into an in-flight Rcon() call via the shared
+	// "rcon"/"rcon_end" topics.
+	server.rconMu.Lock()
+	defer server.rconMu.Unlock()
+
 	// do every daily one
 	for _, rconCommand := range server.rconDaily {
 		server.rconCommand(processCommand(rconCommand, dt))
@@ -212,13 +273,9 @@ func (server *OpenTTDServer) dateChanged(dt time.Time) {
 
 }
 
-func (server OpenTTDServer) rconCommand(command string) {
-
-	var rconCommand []byte
-	rconCommand = append(rconCommand, command...)
-	rconCommand = append(rconCommand, 0000)
-
-	server.sendSocket(adminPacketAdminRCON, rconCommand)
+func (server *OpenTTDServer) rconCommand(command string) {
+	pkt := PacketAdminRcon{Command: command}
+	server.connection.Write(pkt.Bytes())
 }
 
 func processCommand(command string, dt time.Time) string {
@@ -228,18 +285,6 @@ func processCommand(command string, dt time.Time) string {
 	return command
 }
 
-func (server *OpenTTDServer) sendSocket(protocol int, data []byte) {
-	// fmt.Printf("Going to send using protocol %v this data: %v\n", protocol, data)
-	toSend := make([]byte, 3)     // start with 3 bytes for the length and protocol
-	size := uint16(len(data) + 3) // size 2 bytes, plus protocol
-	binary.LittleEndian.PutUint16(toSend, size)
-	// toSend = append(toSend[:],
-	toSend[2] = byte(protocol)
-	toSend = append(toSend, data...)
-	// fmt.Printf("Going to send this: %v\n", toSend)
-	server.connection.Write(toSend)
-}
-
 func (server *OpenTTDServer) listenSocket() {
 
 	// fmt.Println("waiting for connection...")
@@ -255,20 +300,13 @@ SocketLoop:
 		socketData := make([]byte, 1024)
 		s, err := server.connection.Read(socketData)
 		if err != nil {
-			if cErr, ok := err.(*net.OpError); ok {
-				if cErr.Err.Error() == "read: connection reset by peer" {
-					log.Println("Connection reset by peer - check the openttd log for details")
-					server.connection = nil
-					server.disconnected <- true
-					return
-
-				}
+			if errors.Is(err, net.ErrClosed) {
+				log.Println("connection closed")
 			} else {
-				log.Println("Error occurred on socket: ", err)
-				server.connection = nil
-				server.disconnected <- true
-				return
+				log.Println("error occurred on socket:", err)
 			}
+			server.connection = nil
+			server.disconnected <- true
 			return
 		}
 
@@ -300,64 +338,223 @@ SocketLoop:
 			packetData := chunk[3:packetSize]
 			// fmt.Printf("packet type %d and size is %v bytes, I read %d from socket\n", packetType, len(packetData), s)
 
-			if packetType == adminPacketServerPROTOCOL {
-				// fmt.Print(" - Got a adminPacketServerPROTOCOL packet\n")
-			} else if packetType == adminPacketServerWELCOME {
-				log.Println("received welcome packet")
-				fmt.Printf("packet: %+v?\n", packetData[:])
-				var next int
-				server.ServerName, next = extractString(packetData[:], 0)
-				server.ServerVersion, next = extractString(packetData[:], next)
-				if packetData[next] == 0000 {
-					server.ServerDedicated = false
-				} else if packetData[next] == 0001 {
-					server.ServerDedicated = true
-				} else {
-					fmt.Printf("not bool %v?\n", packetData[next])
+			switch packetType {
+			case adminPacketServerFULL:
+				var pkt PacketServerFull
+				pkt.Read(packetData)
+				log.Println("server is full, will not be able to connect")
+				server.setFatal(ErrServerFull)
+				server.connection = nil
+				server.disconnected <- true
+				return
+			case adminPacketServerBANNED:
+				var pkt PacketServerBanned
+				pkt.Read(packetData)
+				log.Println("admin is banned from this server")
+				server.setFatal(ErrBanned)
+				server.connection = nil
+				server.disconnected <- true
+				return
+			case adminPacketServerERROR:
+				var pkt PacketServerError
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad error packet: %v\n", err)
+					break
+				}
+				log.Printf("server reported an error: code %d\n", pkt.ErrorCode)
+				server.setFatal(errorForCode(pkt.ErrorCode))
+				server.connection = nil
+				server.disconnected <- true
+				return
+			case adminPacketServerNEWGAME:
+				var pkt PacketServerNewGame
+				pkt.Read(packetData)
+				log.Println("server is starting a new game")
+			case adminPacketServerPROTOCOL:
+				var pkt PacketServerProtocol
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad protocol packet: %v\n", err)
+				}
+			case adminPacketServerWELCOME:
+				var pkt PacketServerWelcome
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad welcome packet: %v\n", err)
+					break
 				}
-				server.MapName, next = extractString(packetData[:], next+1)
-				server.MapSeed = binary.LittleEndian.Uint32(packetData[next : next+4])
-				server.MapLandscape = packetData[next+4]
-				// todo
-				// p->Send_uint32(ConvertYMDToDate(_settings_game.game_creation.starting_year, 0, 1));
-				server.MapX = binary.LittleEndian.Uint16(packetData[next+9 : next+11])
-				server.MapY = binary.LittleEndian.Uint16(packetData[next+11 : next+13])
+				server.ServerName = pkt.ServerName
+				server.ServerVersion = pkt.ServerVersion
+				server.ServerDedicated = pkt.Dedicated
+				server.MapName = pkt.MapName
+				server.MapSeed = pkt.MapSeed
+				server.MapLandscape = pkt.MapLandscape
+				server.MapX = pkt.MapX
+				server.MapY = pkt.MapY
 
 				log.Printf("server: %s version: %s dedicated: %v map: %s %d/%d size\n", server.ServerName, server.ServerVersion, server.ServerDedicated, server.MapName, server.MapX, server.MapY)
-
-				// fmt.Printf("   * server name: %s\n", ServerName)
-			} else if packetType == adminPacketServerSHUTDOWN {
+			case adminPacketServerSHUTDOWN:
 				log.Println("server shutting down - will try to reconnect")
+				server.emit("shutdown", ShutdownEvent{})
 				server.connection = nil
 				server.disconnected <- true
 				return
-
-			} else if packetType == adminPacketServerDATE {
-				// [[7 0 107 84 252 10 0 0 0
-				date := binary.LittleEndian.Uint32(packetData[0:4])
+			case adminPacketServerDATE:
+				var pkt PacketServerDate
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad date packet: %v\n", err)
+					break
+				}
 				epochDate := time.Date(0, time.January, 1, 0, 0, 0, 0, time.UTC)
-				dt := epochDate.AddDate(0, 0, int(date))
-				// fmt.Printf("   * Date is %v\n", dt)
+				dt := epochDate.AddDate(0, 0, int(pkt.Date))
+				server.trackDate(dt)
 				server.dateChanged(dt)
-				// uint32
-			} else if packetType == adminPacketServerCHAT {
-				// fmt.Printf(" - Got a chat packet:\n%v", packetData)
-				// [3 0 3 0 0 0 98 105 116 104 99 105 110 103 0 0 0 0 0 0 0 0 0]
-				chatAction := int8(packetData[0])
-				chatDestType := int8(packetData[1])
-				chatClientID := binary.LittleEndian.Uint32(packetData[2:6])
-				// var chatMsg string
-				chatMsg, _ := extractString(packetData[:], 6)
-				chatData := binary.LittleEndian.Uint64(packetData[len(packetData)-8:])
-				log.Printf("chat message: action %v desttype %v, client id %v msg %v data %v\n", chatAction, chatDestType, chatClientID, string(chatMsg), chatData)
-			} else if packetType == adminPacketServerRCON {
-				colour := binary.LittleEndian.Uint16(packetData[0:2])
-				rconRecvString, _ := extractString(packetData[:], 2)
-				log.Printf("rcon: colour %v : %s\n", colour, rconRecvString)
-			} else if packetType == adminPacketServerRCON_END {
-				rconEndRecvString, _ := extractString(packetData[:], 0)
-				log.Printf("rcon end : %s\n", rconEndRecvString)
-			} else {
+				server.emit("date", DateEvent{Date: dt})
+			case adminPacketServerCLIENT_JOIN:
+				var pkt PacketServerClientJoin
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad client join packet: %v\n", err)
+					break
+				}
+				server.trackClientJoin(pkt)
+				server.emit("client_join", ClientJoinEvent{ClientID: pkt.ClientID})
+			case adminPacketServerCLIENT_INFO:
+				var pkt PacketServerClientInfo
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad client info packet: %v\n", err)
+					break
+				}
+				server.trackClientInfo(pkt)
+			case adminPacketServerCLIENT_UPDATE:
+				var pkt PacketServerClientUpdate
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad client update packet: %v\n", err)
+					break
+				}
+				server.trackClientUpdate(pkt)
+			case adminPacketServerCLIENT_QUIT:
+				var pkt PacketServerClientQuit
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad client quit packet: %v\n", err)
+					break
+				}
+				server.trackClientQuit(pkt)
+				server.emit("client_quit", ClientQuitEvent{ClientID: pkt.ClientID})
+			case adminPacketServerCLIENT_ERROR:
+				var pkt PacketServerClientError
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad client error packet: %v\n", err)
+					break
+				}
+				server.trackClientError(pkt)
+			case adminPacketServerCOMPANY_NEW:
+				var pkt PacketServerCompanyNew
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company new packet: %v\n", err)
+					break
+				}
+				server.trackCompanyNew(pkt)
+				server.emit("company_new", CompanyNewEvent{CompanyID: pkt.CompanyID})
+			case adminPacketServerCOMPANY_INFO:
+				var pkt PacketServerCompanyInfo
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company info packet: %v\n", err)
+					break
+				}
+				server.trackCompanyInfo(pkt)
+			case adminPacketServerCOMPANY_UPDATE:
+				var pkt PacketServerCompanyUpdate
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company update packet: %v\n", err)
+					break
+				}
+				server.trackCompanyUpdate(pkt)
+			case adminPacketServerCOMPANY_REMOVE:
+				var pkt PacketServerCompanyRemove
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company remove packet: %v\n", err)
+					break
+				}
+				server.trackCompanyRemove(pkt)
+			case adminPacketServerCOMPANY_ECONOMY:
+				var pkt PacketServerCompanyEconomy
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company economy packet: %v\n", err)
+					break
+				}
+				server.trackCompanyEconomy(pkt)
+				server.emit("company_economy", CompanyEconomyEvent{
+					CompanyID:   pkt.CompanyID,
+					Money:       pkt.Money,
+					CurrentLoan: pkt.CurrentLoan,
+					Income:      pkt.Income,
+					Delivered:   pkt.Delivered,
+				})
+			case adminPacketServerCOMPANY_STATS:
+				var pkt PacketServerCompanyStats
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad company stats packet: %v\n", err)
+					break
+				}
+				server.trackCompanyStats(pkt)
+			case adminPacketServerCHAT:
+				var pkt PacketServerChat
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad chat packet: %v\n", err)
+					break
+				}
+				log.Printf("chat message: action %v desttype %v, client id %v msg %v data %v\n", pkt.Action, pkt.DestType, pkt.ClientID, pkt.Message, pkt.Data)
+				server.emit("chat", ChatEvent{Action: pkt.Action, DestType: pkt.DestType, ClientID: pkt.ClientID, Message: pkt.Message})
+			case adminPacketServerRCON:
+				var pkt PacketServerRcon
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad rcon packet: %v\n", err)
+					break
+				}
+				log.Printf("rcon: colour %v : %s\n", pkt.Colour, pkt.Message)
+				server.emit("rcon", RconEvent{Colour: pkt.Colour, Message: pkt.Message})
+			case adminPacketServerCONSOLE:
+				var pkt PacketServerConsole
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad console packet: %v\n", err)
+					break
+				}
+				server.trackConsole(pkt)
+				server.emit("console", ConsoleEvent{Origin: pkt.Origin, Message: pkt.Message})
+			case adminPacketServerCMD_NAMES:
+				var pkt PacketServerCmdNames
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad cmd names packet: %v\n", err)
+					break
+				}
+				server.trackCmdNames(pkt)
+			case adminPacketServerCMD_LOGGING:
+				var pkt PacketServerCmdLogging
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad cmd logging packet: %v\n", err)
+					break
+				}
+			case adminPacketServerGAMESCRIPT:
+				var pkt PacketServerGamescript
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad gamescript packet: %v\n", err)
+					break
+				}
+				server.emit("gamescript", GameScriptEvent{Raw: json.RawMessage(pkt.JSON)})
+			case adminPacketServerPONG:
+				var pkt PacketServerPong
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad pong packet: %v\n", err)
+					break
+				}
+				server.emit("pong", PongEvent{Payload: pkt.Payload})
+			case adminPacketServerRCON_END:
+				var pkt PacketServerRconEnd
+				if err := pkt.Read(packetData); err != nil {
+					log.Printf("bad rcon end packet: %v\n", err)
+					break
+				}
+				log.Printf("rcon end : %s\n", pkt.Command)
+				server.emit("rcon_end", pkt.Command)
+			default:
 				log.Printf("unknown packet received from server: %v [%v]\n", string(packetData), packetData)
 			}
 
@@ -376,14 +573,3 @@ SocketLoop:
 	}
 
 }
-
-func extractString(bytes []byte, start int) (string, int) {
-	var buildString []byte
-	for i := start; i <= len(bytes); i++ {
-		if bytes[i] == 0 {
-			return string(buildString), i + 1
-		}
-		buildString = append(buildString, bytes[i])
-	}
-	return "", -1
-}
@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrServerFull is the fatal error recorded when the server rejects the
+// admin connection because it has no room for another admin.
+var ErrServerFull = errors.New("admin: server is full")
+
+// ErrBanned is the fatal error recorded when the admin's address is banned
+// from the server.
+var ErrBanned = errors.New("admin: banned from server")
+
+// ErrAuthFailed is the fatal error recorded when the server reports a
+// NETWORK_ERROR_WRONG_PASSWORD or NETWORK_ERROR_NOT_AUTHORIZED error, i.e.
+// the admin's JOIN attempt was rejected for bad credentials.
+var ErrAuthFailed = errors.New("admin: authentication failed")
+
+// ErrServerError is the fatal error recorded when the server sends a generic
+// ADMIN_PACKET_SERVER_ERROR for a condition other than bad credentials
+// (desync, kicked, wrong revision, and so on). The underlying
+// NetworkErrorCode is included in the error text.
+var ErrServerError = errors.New("admin: server reported an error")
+
+// NetworkErrorCode values from src/network/core/config.h in the OpenTTD
+// source. Only the ones this package distinguishes between are named; any
+// other code is reported via ErrServerError.
+const (
+	networkErrorNotAuthorized = 6
+	networkErrorWrongPassword = 10
+)
+
+// errorForCode maps a NetworkErrorCode from an ADMIN_PACKET_SERVER_ERROR
+// packet to a fatal error, so callers can tell a bad-password rejection
+// (ErrAuthFailed) apart from the many unrelated conditions the generic
+// ERROR packet also covers.
+func errorForCode(code uint8) error {
+	switch code {
+	case networkErrorNotAuthorized, networkErrorWrongPassword:
+		return ErrAuthFailed
+	default:
+		return fmt.Errorf("%w: code %d", ErrServerError, code)
+	}
+}
@@ -0,0 +1,24 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorForCodeMapsAuthFailures(t *testing.T) {
+	for _, code := range []uint8{networkErrorWrongPassword, networkErrorNotAuthorized} {
+		if err := errorForCode(code); !errors.Is(err, ErrAuthFailed) {
+			t.Fatalf("errorForCode(%d) = %v, want ErrAuthFailed", code, err)
+		}
+	}
+}
+
+func TestErrorForCodeWrapsUnrelatedCodes(t *testing.T) {
+	err := errorForCode(2) // NETWORK_ERROR_SAVEGAME_FAILED
+	if errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("errorForCode(2) = %v, should not match ErrAuthFailed", err)
+	}
+	if !errors.Is(err, ErrServerError) {
+		t.Fatalf("errorForCode(2) = %v, want ErrServerError", err)
+	}
+}
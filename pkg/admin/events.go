@@ -0,0 +1,358 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+)
+
+// maxGamescriptJSONLength is NETWORK_GAMESCRIPT_JSON_LENGTH from the
+// OpenTTD source - the largest JSON payload the GameScript channel will
+// accept.
+const maxGamescriptJSONLength = 1450
+
+// ErrGameScriptTooLarge is returned by SendGameScript when the marshalled
+// payload exceeds the protocol's length limit.
+var ErrGameScriptTooLarge = errors.New("admin: gamescript JSON payload exceeds protocol limit")
+
+// eventBufferSize is how many pending events a slow subscriber can queue
+// behind before newer events are dropped on its behalf.
+const eventBufferSize = 32
+
+// ChatEvent is delivered by OnChat whenever a chat message is relayed by
+// the server.
+type ChatEvent struct {
+	Action   int8
+	DestType int8
+	ClientID uint32
+	Message  string
+}
+
+// ClientJoinEvent is delivered by OnClientJoin when a client joins.
+type ClientJoinEvent struct {
+	ClientID uint32
+}
+
+// ClientQuitEvent is delivered by OnClientQuit when a client quits.
+type ClientQuitEvent struct {
+	ClientID uint32
+}
+
+// CompanyNewEvent is delivered by OnCompanyNew when a new company starts.
+type CompanyNewEvent struct {
+	CompanyID uint8
+}
+
+// CompanyEconomyEvent is delivered by OnCompanyEconomy whenever the server
+// reports a company's economy information.
+type CompanyEconomyEvent struct {
+	CompanyID   uint8
+	Money       int64
+	CurrentLoan uint64
+	Income      int64
+	Delivered   uint16
+}
+
+// ShutdownEvent is delivered by OnShutdown when the server announces that
+// it is shutting down.
+type ShutdownEvent struct{}
+
+// ConsoleEvent is delivered by OnConsole for everything printed to the
+// server's console.
+type ConsoleEvent struct {
+	Origin  string
+	Message string
+}
+
+// RconEvent is delivered by OnRcon for each line of an RCON command's reply.
+type RconEvent struct {
+	Colour  uint16
+	Message string
+}
+
+// GameScriptEvent is delivered by OnGameScript for JSON sent by the running
+// GameScript.
+type GameScriptEvent struct {
+	Raw json.RawMessage
+}
+
+// DateEvent is delivered by OnDate whenever the in-game date changes.
+type DateEvent struct {
+	Date time.Time
+}
+
+// PongEvent is delivered by OnPong in reply to a Ping.
+type PongEvent struct {
+	Payload uint32
+}
+
+// subscriber forwards delivered events to a user handler from a single
+// goroutine, reading off a buffered channel, so a slow handler can never
+// stall the socket reader that produces the events.
+type subscriber struct {
+	ch chan interface{}
+}
+
+func newSubscriber(handler func(interface{})) *subscriber {
+	s := &subscriber{ch: make(chan interface{}, eventBufferSize)}
+	go func() {
+		for evt := range s.ch {
+			handler(evt)
+		}
+	}()
+	return s
+}
+
+func (s *subscriber) deliver(evt interface{}) {
+	select {
+	case s.ch <- evt:
+	default:
+		log.Printf("admin: subscriber queue full, dropping %T event\n", evt)
+	}
+}
+
+func (server *OpenTTDServer) subscribe(name string, handler func(interface{})) {
+	server.subscribeTemp(name, handler)
+}
+
+// subscribeTemp registers a handler like subscribe, but returns a function
+// that removes the subscription again. It backs synchronous helpers like
+// Rcon that only need to listen for the duration of a single call.
+func (server *OpenTTDServer) subscribeTemp(name string, handler func(interface{})) func() {
+	return server.subscribeTempMulti([]string{name}, handler)
+}
+
+// subscribeTempMulti is subscribeTemp for more than one event name, backed
+// by a single subscriber goroutine shared across all of them. Because emit()
+// is always called from the one connection-reading goroutine, and a shared
+// subscriber drains its buffered channel in the order events were delivered
+// to it, handler sees events for every name in their true arrival order -
+// unlike subscribing to each name separately, which gives each name its own
+// independently-scheduled dispatch goroutine with no ordering guarantee
+// between them. Rcon/RconStream rely on this to keep "rcon" lines from
+// racing past the "rcon_end" that follows them.
+func (server *OpenTTDServer) subscribeTempMulti(names []string, handler func(interface{})) func() {
+	server.eventsMu.Lock()
+	defer server.eventsMu.Unlock()
+	if server.subscribers == nil {
+		server.subscribers = make(map[string][]*subscriber)
+	}
+	sub := newSubscriber(handler)
+	for _, name := range names {
+		server.subscribers[name] = append(server.subscribers[name], sub)
+	}
+
+	return func() {
+		server.eventsMu.Lock()
+		defer server.eventsMu.Unlock()
+		for _, name := range names {
+			subs := server.subscribers[name]
+			for i, s := range subs {
+				if s == sub {
+					server.subscribers[name] = append(subs[:i], subs[i+1:]...)
+					break
+				}
+			}
+		}
+		close(sub.ch)
+	}
+}
+
+func (server *OpenTTDServer) emit(name string, evt interface{}) {
+	server.eventsMu.Lock()
+	subs := server.subscribers[name]
+	server.eventsMu.Unlock()
+	for _, s := range subs {
+		s.deliver(evt)
+	}
+}
+
+// OnChat registers a handler that is called for every chat message relayed
+// by the server.
+func (server *OpenTTDServer) OnChat(handler func(ChatEvent)) {
+	server.subscribe("chat", func(evt interface{}) { handler(evt.(ChatEvent)) })
+}
+
+// OnClientJoin registers a handler that is called whenever a client joins.
+func (server *OpenTTDServer) OnClientJoin(handler func(ClientJoinEvent)) {
+	server.subscribe("client_join", func(evt interface{}) { handler(evt.(ClientJoinEvent)) })
+}
+
+// OnClientQuit registers a handler that is called whenever a client quits.
+func (server *OpenTTDServer) OnClientQuit(handler func(ClientQuitEvent)) {
+	server.subscribe("client_quit", func(evt interface{}) { handler(evt.(ClientQuitEvent)) })
+}
+
+// OnCompanyNew registers a handler that is called whenever a new company
+// starts.
+func (server *OpenTTDServer) OnCompanyNew(handler func(CompanyNewEvent)) {
+	server.subscribe("company_new", func(evt interface{}) { handler(evt.(CompanyNewEvent)) })
+}
+
+// OnCompanyEconomy registers a handler that is called whenever the server
+// reports a company's economy information.
+func (server *OpenTTDServer) OnCompanyEconomy(handler func(CompanyEconomyEvent)) {
+	server.subscribe("company_economy", func(evt interface{}) { handler(evt.(CompanyEconomyEvent)) })
+}
+
+// OnShutdown registers a handler that is called when the server announces
+// that it is shutting down.
+func (server *OpenTTDServer) OnShutdown(handler func(ShutdownEvent)) {
+	server.subscribe("shutdown", func(evt interface{}) { handler(evt.(ShutdownEvent)) })
+}
+
+// OnConsole registers a handler that is called for everything printed to
+// the server's console.
+func (server *OpenTTDServer) OnConsole(handler func(ConsoleEvent)) {
+	server.subscribe("console", func(evt interface{}) { handler(evt.(ConsoleEvent)) })
+}
+
+// OnRcon registers a handler that is called for each line of an RCON
+// command's reply.
+func (server *OpenTTDServer) OnRcon(handler func(RconEvent)) {
+	server.subscribe("rcon", func(evt interface{}) { handler(evt.(RconEvent)) })
+}
+
+// OnGameScript registers a handler that is called for JSON sent by the
+// running GameScript.
+func (server *OpenTTDServer) OnGameScript(handler func(GameScriptEvent)) {
+	server.subscribe("gamescript", func(evt interface{}) { handler(evt.(GameScriptEvent)) })
+}
+
+// OnGameScriptJSON registers a handler that unmarshals incoming GameScript
+// JSON into a newly allocated value of prototype's type (its element type if
+// prototype is a pointer) and calls handler with a pointer to that value, so
+// callers don't have to unmarshal json.RawMessage themselves.
+func (server *OpenTTDServer) OnGameScriptJSON(prototype interface{}, handler func(interface{})) {
+	t := reflect.TypeOf(prototype)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	server.OnGameScript(func(evt GameScriptEvent) {
+		v := reflect.New(t).Interface()
+		if err := json.Unmarshal(evt.Raw, v); err != nil {
+			log.Printf("admin: OnGameScriptJSON: %v\n", err)
+			return
+		}
+		handler(v)
+	})
+}
+
+// OnDate registers a handler that is called whenever the in-game date
+// changes.
+func (server *OpenTTDServer) OnDate(handler func(DateEvent)) {
+	server.subscribe("date", func(evt interface{}) { handler(evt.(DateEvent)) })
+}
+
+// OnPong registers a handler that is called when the server replies to a
+// Ping.
+func (server *OpenTTDServer) OnPong(handler func(PongEvent)) {
+	server.subscribe("pong", func(evt interface{}) { handler(evt.(PongEvent)) })
+}
+
+// SendChat sends a chat message to be distributed by the server.
+func (server *OpenTTDServer) SendChat(action uint8, destType uint8, destID uint32, message string) {
+	pkt := PacketAdminChat{Action: action, DestType: destType, DestID: destID, Message: message}
+	server.connection.Write(pkt.Bytes())
+}
+
+// SendRcon sends a remote console command. Like Rcon and RconStream, it is
+// serialized against other RCON activity (including the cron-style commands
+// dateChanged fires) since the admin protocol has no way to correlate an
+// RCON reply with the command that produced it.
+func (server *OpenTTDServer) SendRcon(command string) {
+	server.rconMu.Lock()
+	defer server.rconMu.Unlock()
+	server.rconCommand(command)
+}
+
+// SendGameScript marshals v to JSON and sends it to the running GameScript
+// over the admin protocol's GameScript channel. It returns
+// ErrGameScriptTooLarge if the encoded payload exceeds the protocol's
+// length limit.
+func (server *OpenTTDServer) SendGameScript(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(b) > maxGamescriptJSONLength {
+		return fmt.Errorf("%w: %d bytes", ErrGameScriptTooLarge, len(b))
+	}
+	pkt := PacketAdminGamescript{JSON: string(b)}
+	server.connection.Write(pkt.Bytes())
+	return nil
+}
+
+// SendGameScriptRaw sends an already-encoded JSON payload to the running
+// GameScript, for callers that built the message themselves instead of
+// marshalling a Go value via SendGameScript.
+func (server *OpenTTDServer) SendGameScriptRaw(raw json.RawMessage) error {
+	if len(raw) > maxGamescriptJSONLength {
+		return fmt.Errorf("%w: %d bytes", ErrGameScriptTooLarge, len(raw))
+	}
+	pkt := PacketAdminGamescript{JSON: string(raw)}
+	server.connection.Write(pkt.Bytes())
+	return nil
+}
+
+// SendPing sends a ping to the server, expecting a PONG in reply.
+func (server *OpenTTDServer) SendPing(payload uint32) {
+	pkt := PacketAdminPing{Payload: payload}
+	server.connection.Write(pkt.Bytes())
+}
+
+// Poll explicitly polls the server for a piece of information.
+func (server *OpenTTDServer) Poll(pollType uint8, extraID uint32) {
+	pkt := PacketAdminPoll{PollType: pollType, ExtraID: extraID}
+	server.connection.Write(pkt.Bytes())
+}
+
+// PollClientInfo polls for the full client list, refreshing the state
+// cache returned by ListClients/GetClient.
+func (server *OpenTTDServer) PollClientInfo() {
+	server.Poll(adminUpdateCLIENT_INFO, 0xFFFFFFFF)
+}
+
+// PollCompanyInfo polls for the full company list, refreshing the state
+// cache returned by ListCompanies/GetCompany.
+func (server *OpenTTDServer) PollCompanyInfo() {
+	server.Poll(adminUpdateCOMPANY_INFO, 0xFFFFFFFF)
+}
+
+// PollCompanyEconomy polls for economy information (money, loan, income)
+// for every company.
+func (server *OpenTTDServer) PollCompanyEconomy() {
+	server.Poll(adminUpdateCOMPANY_ECONOMY, 0xFFFFFFFF)
+}
+
+// PollCompanyStats polls for vehicle and station counts for every company.
+func (server *OpenTTDServer) PollCompanyStats() {
+	server.Poll(adminUpdateCOMPANY_STATS, 0xFFFFFFFF)
+}
+
+// PollDate polls for the current in-game date.
+func (server *OpenTTDServer) PollDate() {
+	server.Poll(adminUpdateDATE, 0)
+}
+
+// PollCmdNames polls for the names of the DoCommands the server may report
+// via PacketServerCmdLogging, refreshing the cache returned by CmdNames.
+func (server *OpenTTDServer) PollCmdNames() {
+	server.Poll(adminUpdateCMD_NAMES, 0)
+}
+
+// SetUpdateFrequency subscribes to (or unsubscribes from) updates for a
+// particular piece of information. The subscription is remembered and
+// replayed automatically if the connection is lost and re-established.
+func (server *OpenTTDServer) SetUpdateFrequency(update uint16, frequency uint16) {
+	pkt := PacketAdminUpdateFrequency{Update: update, Frequency: frequency}
+
+	server.updateFrequenciesMu.Lock()
+	server.updateFrequencies = append(server.updateFrequencies, pkt)
+	server.updateFrequenciesMu.Unlock()
+
+	server.connection.Write(pkt.Bytes())
+}
@@ -0,0 +1,195 @@
+package admin
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnChatDeliversEvent(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var got ChatEvent
+	server.OnChat(func(evt ChatEvent) {
+		got = evt
+		wg.Done()
+	})
+
+	server.emit("chat", ChatEvent{ClientID: 7, Message: "hello"})
+
+	wg.Wait()
+	if got.ClientID != 7 || got.Message != "hello" {
+		t.Fatalf("unexpected event: %+v", got)
+	}
+}
+
+func TestEmitDoesNotBlockOnSlowSubscriber(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	blocked := make(chan struct{})
+	server.OnDate(func(evt DateEvent) {
+		<-blocked
+	})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventBufferSize+10; i++ {
+			server.emit("date", DateEvent{})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("emit blocked on a slow subscriber")
+	}
+	close(blocked)
+}
+
+func TestOnCompanyEconomyDeliversEvent(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var got CompanyEconomyEvent
+	server.OnCompanyEconomy(func(evt CompanyEconomyEvent) {
+		got = evt
+		wg.Done()
+	})
+
+	server.emit("company_economy", CompanyEconomyEvent{CompanyID: 3, Money: 1000})
+
+	wg.Wait()
+	if got.CompanyID != 3 || got.Money != 1000 {
+		t.Fatalf("unexpected event: %+v", got)
+	}
+}
+
+func TestOnShutdownDeliversEvent(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	done := make(chan struct{})
+	server.OnShutdown(func(evt ShutdownEvent) {
+		close(done)
+	})
+
+	server.emit("shutdown", ShutdownEvent{})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnShutdown handler was not called")
+	}
+}
+
+func TestPollCompanyEconomySendsCorrectPollType(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn}
+
+	read := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 1024)
+		n, _ := serverConn.Read(buf)
+		read <- buf[:n]
+	}()
+
+	server.PollCompanyEconomy()
+
+	got := <-read
+	if got[2] != adminPacketAdminPOLL {
+		t.Fatalf("unexpected packet type: %d", got[2])
+	}
+	if got[3] != adminUpdateCOMPANY_ECONOMY {
+		t.Fatalf("unexpected poll type: %d", got[3])
+	}
+	if extraID := binary.LittleEndian.Uint32(got[4:8]); extraID != 0xFFFFFFFF {
+		t.Fatalf("unexpected extra id: %#x", extraID)
+	}
+}
+
+func TestSendGameScriptRejectsOversizedPayload(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	huge := make([]byte, maxGamescriptJSONLength)
+	err := server.SendGameScript(map[string]string{"data": string(huge)})
+	if err == nil {
+		t.Fatal("expected an error for an oversized payload")
+	}
+}
+
+func TestSendGameScriptRawRejectsOversizedPayload(t *testing.T) {
+	server := &OpenTTDServer{}
+
+	err := server.SendGameScriptRaw(json.RawMessage(make([]byte, maxGamescriptJSONLength+1)))
+	if err == nil {
+		t.Fatal("expected an error for an oversized payload")
+	}
+}
+
+func TestOnGameScriptReceivesReplyAfterSendGameScriptRaw(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn}
+
+	var got GameScriptEvent
+	done := make(chan struct{})
+	server.OnGameScript(func(evt GameScriptEvent) {
+		got = evt
+		close(done)
+	})
+
+	go func() {
+		buf := make([]byte, 1024)
+		serverConn.Read(buf)
+		server.emit("gamescript", GameScriptEvent{Raw: json.RawMessage(`{"company_id":1,"cash":50000}`)})
+	}()
+
+	if err := server.SendGameScriptRaw(json.RawMessage(`{"hello":"world"}`)); err != nil {
+		t.Fatalf("SendGameScriptRaw() error: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnGameScript handler was not called")
+	}
+	if string(got.Raw) != `{"company_id":1,"cash":50000}` {
+		t.Fatalf("unexpected gamescript payload: %s", got.Raw)
+	}
+}
+
+// ExampleOpenTTDServer_OnGameScriptJSON shows how a GS that reports company
+// cash as JSON (e.g. `{"company_id":1,"cash":50000}`) can be consumed
+// without the caller unmarshalling json.RawMessage by hand.
+func ExampleOpenTTDServer_OnGameScriptJSON() {
+	server := &OpenTTDServer{}
+
+	type CompanyCash struct {
+		CompanyID int   `json:"company_id"`
+		Cash      int64 `json:"cash"`
+	}
+
+	done := make(chan struct{})
+	server.OnGameScriptJSON(CompanyCash{}, func(v interface{}) {
+		cash := v.(*CompanyCash)
+		fmt.Printf("company %d has %d cash\n", cash.CompanyID, cash.Cash)
+		close(done)
+	})
+
+	server.emit("gamescript", GameScriptEvent{Raw: json.RawMessage(`{"company_id":1,"cash":50000}`)})
+	<-done
+
+	// Output: company 1 has 50000 cash
+}
@@ -0,0 +1,38 @@
+package admin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloseStopsReconnecting(t *testing.T) {
+	server := &OpenTTDServer{}
+	server.done = make(chan struct{})
+
+	server.Close()
+
+	if !server.shouldStop() {
+		t.Fatal("expected shouldStop to report true after Close")
+	}
+	server.stop()
+
+	select {
+	case <-server.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done channel was not closed")
+	}
+}
+
+func TestSetFatalIsReturnedByErr(t *testing.T) {
+	server := &OpenTTDServer{}
+	server.done = make(chan struct{})
+
+	server.setFatal(ErrBanned)
+
+	if server.Err() != ErrBanned {
+		t.Fatalf("Err() = %v, want %v", server.Err(), ErrBanned)
+	}
+	if !server.shouldStop() {
+		t.Fatal("expected shouldStop to report true after a fatal error")
+	}
+}
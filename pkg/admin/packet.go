@@ -0,0 +1,721 @@
+package admin
+
+// Typed encodings for the admin protocol packets. Every packet on the wire
+// is framed as a little-endian uint16 length (covering the whole packet,
+// including this header), a uint8 packet type, and a type-specific payload.
+// Strings in the payload are zero-terminated ("C strings").
+//
+// See the references at the top of admin.go for the wire format this
+// mirrors.
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// errShortPacket is returned by Read when the supplied bytes are too short
+// to contain the fields the packet requires.
+var errShortPacket = errors.New("admin: packet too short")
+
+// Packet is the header embedded in every admin protocol packet.
+type Packet struct {
+	PLength uint16
+	PType   uint8
+}
+
+// framePacket prepends the length+type header to an already-encoded payload.
+func framePacket(pType uint8, payload []byte) []byte {
+	b := make([]byte, 3, 3+len(payload))
+	binary.LittleEndian.PutUint16(b, uint16(3+len(payload)))
+	b[2] = pType
+	return append(b, payload...)
+}
+
+// appendCString appends s to b followed by a terminating zero byte.
+func appendCString(b []byte, s string) []byte {
+	b = append(b, s...)
+	return append(b, 0x0)
+}
+
+// readCString reads a zero-terminated string from b starting at start,
+// returning the string and the index of the byte following the terminator.
+func readCString(b []byte, start int) (string, int, error) {
+	for i := start; i < len(b); i++ {
+		if b[i] == 0x0 {
+			return string(b[start:i]), i + 1, nil
+		}
+	}
+	return "", 0, errShortPacket
+}
+
+//
+// admin -> server packets
+//
+
+// PacketAdminJoin authenticates the admin with the server.
+type PacketAdminJoin struct {
+	Packet
+	Password      string
+	ClientName    string
+	ClientVersion string
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminJoin) Bytes() []byte {
+	var b []byte
+	b = appendCString(b, p.Password)
+	b = appendCString(b, p.ClientName)
+	b = appendCString(b, p.ClientVersion)
+	return framePacket(adminPacketAdminJOIN, b)
+}
+
+// PacketAdminUpdateFrequency subscribes to (or unsubscribes from) updates
+// for a particular piece of information.
+type PacketAdminUpdateFrequency struct {
+	Packet
+	Update    uint16
+	Frequency uint16
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminUpdateFrequency) Bytes() []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint16(b[0:2], p.Update)
+	binary.LittleEndian.PutUint16(b[2:4], p.Frequency)
+	return framePacket(adminPacketAdminUPDATE_FREQUENCY, b)
+}
+
+// PacketAdminPoll explicitly polls the server for a piece of information.
+type PacketAdminPoll struct {
+	Packet
+	PollType uint8
+	ExtraID  uint32
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminPoll) Bytes() []byte {
+	b := make([]byte, 5)
+	b[0] = p.PollType
+	binary.LittleEndian.PutUint32(b[1:5], p.ExtraID)
+	return framePacket(adminPacketAdminPOLL, b)
+}
+
+// PacketAdminChat sends a chat message to be distributed by the server.
+type PacketAdminChat struct {
+	Packet
+	Action   uint8
+	DestType uint8
+	DestID   uint32
+	Message  string
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminChat) Bytes() []byte {
+	b := make([]byte, 6)
+	b[0] = p.Action
+	b[1] = p.DestType
+	binary.LittleEndian.PutUint32(b[2:6], p.DestID)
+	b = appendCString(b, p.Message)
+	return framePacket(adminPacketAdminCHAT, b)
+}
+
+// PacketAdminRcon sends a remote console command.
+type PacketAdminRcon struct {
+	Packet
+	Command string
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminRcon) Bytes() []byte {
+	b := appendCString(nil, p.Command)
+	return framePacket(adminPacketAdminRCON, b)
+}
+
+// PacketAdminGamescript sends a JSON string to the running GameScript.
+type PacketAdminGamescript struct {
+	Packet
+	JSON string
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminGamescript) Bytes() []byte {
+	b := appendCString(nil, p.JSON)
+	return framePacket(adminPacketAdminGAMESCRIPT, b)
+}
+
+// PacketAdminPing sends a ping to the server, expecting a PONG in reply.
+type PacketAdminPing struct {
+	Packet
+	Payload uint32
+}
+
+// Bytes encodes the packet for writing to the socket.
+func (p *PacketAdminPing) Bytes() []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, p.Payload)
+	return framePacket(adminPacketAdminPING, b)
+}
+
+//
+// server -> admin packets
+//
+
+// PacketServerProtocol describes the protocol version the server supports.
+type PacketServerProtocol struct {
+	Packet
+	Version uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerProtocol) Read(b []byte) error {
+	if len(b) < 1 {
+		return errShortPacket
+	}
+	p.Version = b[0]
+	return nil
+}
+
+// PacketServerFull is sent when the server cannot accept the admin
+// connection because it is full.
+type PacketServerFull struct {
+	Packet
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+// PacketServerFull carries no payload.
+func (p *PacketServerFull) Read(b []byte) error {
+	return nil
+}
+
+// PacketServerBanned is sent when the admin's address is banned from the
+// server.
+type PacketServerBanned struct {
+	Packet
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+// PacketServerBanned carries no payload.
+func (p *PacketServerBanned) Read(b []byte) error {
+	return nil
+}
+
+// PacketServerError is sent when the server encountered an error and is
+// about to close the connection.
+type PacketServerError struct {
+	Packet
+	ErrorCode uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerError) Read(b []byte) error {
+	if len(b) < 1 {
+		return errShortPacket
+	}
+	p.ErrorCode = b[0]
+	return nil
+}
+
+// PacketServerNewGame is sent when the server is about to start a new game
+// (e.g. after loading a new map).
+type PacketServerNewGame struct {
+	Packet
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+// PacketServerNewGame carries no payload.
+func (p *PacketServerNewGame) Read(b []byte) error {
+	return nil
+}
+
+// PacketServerWelcome welcomes the admin to a game.
+type PacketServerWelcome struct {
+	Packet
+	ServerName    string
+	ServerVersion string
+	Dedicated     bool
+	MapName       string
+	MapSeed       uint32
+	MapLandscape  uint8
+	MapX          uint16
+	MapY          uint16
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerWelcome) Read(b []byte) error {
+	var next int
+	var err error
+	if p.ServerName, next, err = readCString(b, 0); err != nil {
+		return err
+	}
+	if p.ServerVersion, next, err = readCString(b, next); err != nil {
+		return err
+	}
+	if next+1 > len(b) {
+		return errShortPacket
+	}
+	p.Dedicated = b[next] != 0x0
+	next++
+	if p.MapName, next, err = readCString(b, next); err != nil {
+		return err
+	}
+	// next+4 (seed) + 1 (landscape) + 4 (starting date, not yet exposed) + 2 + 2 (map size)
+	if next+13 > len(b) {
+		return errShortPacket
+	}
+	p.MapSeed = binary.LittleEndian.Uint32(b[next : next+4])
+	p.MapLandscape = b[next+4]
+	p.MapX = binary.LittleEndian.Uint16(b[next+9 : next+11])
+	p.MapY = binary.LittleEndian.Uint16(b[next+11 : next+13])
+	return nil
+}
+
+// PacketServerDate reports the current in-game date.
+type PacketServerDate struct {
+	Packet
+	Date uint32
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerDate) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.Date = binary.LittleEndian.Uint32(b[0:4])
+	return nil
+}
+
+// PacketServerClientJoin announces that a client has joined.
+type PacketServerClientJoin struct {
+	Packet
+	ClientID uint32
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerClientJoin) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	return nil
+}
+
+// PacketServerClientInfo gives information about a client.
+type PacketServerClientInfo struct {
+	Packet
+	ClientID  uint32
+	Hostname  string
+	Name      string
+	Language  uint8
+	JoinDate  uint32
+	CompanyID uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerClientInfo) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	var next int
+	var err error
+	if p.Hostname, next, err = readCString(b, 4); err != nil {
+		return err
+	}
+	if p.Name, next, err = readCString(b, next); err != nil {
+		return err
+	}
+	if next+6 > len(b) {
+		return errShortPacket
+	}
+	p.Language = b[next]
+	p.JoinDate = binary.LittleEndian.Uint32(b[next+1 : next+5])
+	p.CompanyID = b[next+5]
+	return nil
+}
+
+// PacketServerClientUpdate gives an information update on a client.
+type PacketServerClientUpdate struct {
+	Packet
+	ClientID  uint32
+	Name      string
+	CompanyID uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerClientUpdate) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	name, next, err := readCString(b, 4)
+	if err != nil {
+		return err
+	}
+	p.Name = name
+	if next+1 > len(b) {
+		return errShortPacket
+	}
+	p.CompanyID = b[next]
+	return nil
+}
+
+// PacketServerClientQuit announces that a client has quit.
+type PacketServerClientQuit struct {
+	Packet
+	ClientID uint32
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerClientQuit) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	return nil
+}
+
+// PacketServerClientError announces that a client caused an error.
+type PacketServerClientError struct {
+	Packet
+	ClientID  uint32
+	ErrorCode uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerClientError) Read(b []byte) error {
+	if len(b) < 5 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	p.ErrorCode = b[4]
+	return nil
+}
+
+// PacketServerCompanyNew announces that a new company has started.
+type PacketServerCompanyNew struct {
+	Packet
+	CompanyID uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyNew) Read(b []byte) error {
+	if len(b) < 1 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	return nil
+}
+
+// PacketServerCompanyInfo gives information about a company.
+type PacketServerCompanyInfo struct {
+	Packet
+	CompanyID         uint8
+	Name              string
+	ManagerName       string
+	Colour            uint8
+	PasswordProtected bool
+	StartYear         uint32
+	IsAI              bool
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyInfo) Read(b []byte) error {
+	if len(b) < 1 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	var next int
+	var err error
+	if p.Name, next, err = readCString(b, 1); err != nil {
+		return err
+	}
+	if p.ManagerName, next, err = readCString(b, next); err != nil {
+		return err
+	}
+	if next+7 > len(b) {
+		return errShortPacket
+	}
+	p.Colour = b[next]
+	p.PasswordProtected = b[next+1] != 0x0
+	p.StartYear = binary.LittleEndian.Uint32(b[next+2 : next+6])
+	p.IsAI = b[next+6] != 0x0
+	return nil
+}
+
+// PacketServerCompanyUpdate gives an information update on a company.
+type PacketServerCompanyUpdate struct {
+	Packet
+	CompanyID         uint8
+	Name              string
+	ManagerName       string
+	Colour            uint8
+	PasswordProtected bool
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyUpdate) Read(b []byte) error {
+	if len(b) < 1 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	var next int
+	var err error
+	if p.Name, next, err = readCString(b, 1); err != nil {
+		return err
+	}
+	if p.ManagerName, next, err = readCString(b, next); err != nil {
+		return err
+	}
+	if next+2 > len(b) {
+		return errShortPacket
+	}
+	p.Colour = b[next]
+	p.PasswordProtected = b[next+1] != 0x0
+	return nil
+}
+
+// PacketServerCompanyRemove announces that a company was removed.
+type PacketServerCompanyRemove struct {
+	Packet
+	CompanyID uint8
+	Reason    uint8
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyRemove) Read(b []byte) error {
+	if len(b) < 2 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	p.Reason = b[1]
+	return nil
+}
+
+// PacketServerCompanyEconomy gives economy related company information.
+type PacketServerCompanyEconomy struct {
+	Packet
+	CompanyID   uint8
+	Money       int64
+	CurrentLoan uint64
+	Income      int64
+	Delivered   uint16
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyEconomy) Read(b []byte) error {
+	if len(b) < 27 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	p.Money = int64(binary.LittleEndian.Uint64(b[1:9]))
+	p.CurrentLoan = binary.LittleEndian.Uint64(b[9:17])
+	p.Income = int64(binary.LittleEndian.Uint64(b[17:25]))
+	p.Delivered = binary.LittleEndian.Uint16(b[25:27])
+	return nil
+}
+
+// PacketServerCompanyStats gives vehicle and station counts for a company.
+type PacketServerCompanyStats struct {
+	Packet
+	CompanyID uint8
+	Vehicles  [5]uint16 // train, lorry, bus, plane, ship
+	Stations  [5]uint16
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCompanyStats) Read(b []byte) error {
+	if len(b) < 21 {
+		return errShortPacket
+	}
+	p.CompanyID = b[0]
+	for i := 0; i < 5; i++ {
+		p.Vehicles[i] = binary.LittleEndian.Uint16(b[1+i*2 : 3+i*2])
+	}
+	for i := 0; i < 5; i++ {
+		p.Stations[i] = binary.LittleEndian.Uint16(b[11+i*2 : 13+i*2])
+	}
+	return nil
+}
+
+// PacketServerChat relays a chat message.
+type PacketServerChat struct {
+	Packet
+	Action   int8
+	DestType int8
+	ClientID uint32
+	Message  string
+	Data     uint64
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerChat) Read(b []byte) error {
+	// header (6) + empty C-string terminator (1) + trailing Data (8)
+	if len(b) < 15 {
+		return errShortPacket
+	}
+	p.Action = int8(b[0])
+	p.DestType = int8(b[1])
+	p.ClientID = binary.LittleEndian.Uint32(b[2:6])
+	msg, end, err := readCString(b, 6)
+	if err != nil {
+		return err
+	}
+	if len(b)-end < 8 {
+		return errShortPacket
+	}
+	p.Message = msg
+	p.Data = binary.LittleEndian.Uint64(b[len(b)-8:])
+	return nil
+}
+
+// PacketServerRcon is one line of a remote console command's reply.
+type PacketServerRcon struct {
+	Packet
+	Colour  uint16
+	Message string
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerRcon) Read(b []byte) error {
+	if len(b) < 2 {
+		return errShortPacket
+	}
+	p.Colour = binary.LittleEndian.Uint16(b[0:2])
+	msg, _, err := readCString(b, 2)
+	if err != nil {
+		return err
+	}
+	p.Message = msg
+	return nil
+}
+
+// PacketServerRconEnd indicates that a remote console command has completed.
+type PacketServerRconEnd struct {
+	Packet
+	Command string
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerRconEnd) Read(b []byte) error {
+	cmd, _, err := readCString(b, 0)
+	if err != nil {
+		return err
+	}
+	p.Command = cmd
+	return nil
+}
+
+// PacketServerConsole gives the data that was printed to the server console.
+type PacketServerConsole struct {
+	Packet
+	Origin  string
+	Message string
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerConsole) Read(b []byte) error {
+	origin, next, err := readCString(b, 0)
+	if err != nil {
+		return err
+	}
+	p.Origin = origin
+	msg, _, err := readCString(b, next)
+	if err != nil {
+		return err
+	}
+	p.Message = msg
+	return nil
+}
+
+// PacketServerCmdNames gives the names of the DoCommands the server may
+// report via PacketServerCmdLogging, keyed by command ID. The server can
+// split the full list across several packets; callers accumulate the
+// entries from each one.
+type PacketServerCmdNames struct {
+	Packet
+	Names map[uint16]string
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCmdNames) Read(b []byte) error {
+	p.Names = make(map[uint16]string)
+	next := 0
+	for next+2 <= len(b) {
+		id := binary.LittleEndian.Uint16(b[next : next+2])
+		name, n, err := readCString(b, next+2)
+		if err != nil {
+			return err
+		}
+		p.Names[id] = name
+		next = n
+	}
+	return nil
+}
+
+// PacketServerCmdLogging carries a copy of a DoCommand as it was executed
+// on the server, for admins that subscribed to adminUpdateCMD_LOGGING.
+type PacketServerCmdLogging struct {
+	Packet
+	ClientID  uint32
+	CompanyID uint8
+	CommandID uint16
+	P1        uint32
+	P2        uint32
+	Tile      uint32
+	Text      string
+	Frame     uint32
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerCmdLogging) Read(b []byte) error {
+	if len(b) < 19 {
+		return errShortPacket
+	}
+	p.ClientID = binary.LittleEndian.Uint32(b[0:4])
+	p.CompanyID = b[4]
+	p.CommandID = binary.LittleEndian.Uint16(b[5:7])
+	p.P1 = binary.LittleEndian.Uint32(b[7:11])
+	p.P2 = binary.LittleEndian.Uint32(b[11:15])
+	p.Tile = binary.LittleEndian.Uint32(b[15:19])
+	text, next, err := readCString(b, 19)
+	if err != nil {
+		return err
+	}
+	p.Text = text
+	if next+4 > len(b) {
+		return errShortPacket
+	}
+	p.Frame = binary.LittleEndian.Uint32(b[next : next+4])
+	return nil
+}
+
+// PacketServerGamescript carries a JSON payload from the GameScript.
+type PacketServerGamescript struct {
+	Packet
+	JSON string
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerGamescript) Read(b []byte) error {
+	json, _, err := readCString(b, 0)
+	if err != nil {
+		return err
+	}
+	p.JSON = json
+	return nil
+}
+
+// PacketServerPong replies to an admin ping request.
+type PacketServerPong struct {
+	Packet
+	Payload uint32
+}
+
+// Read decodes the packet payload (the bytes following the common header).
+func (p *PacketServerPong) Read(b []byte) error {
+	if len(b) < 4 {
+		return errShortPacket
+	}
+	p.Payload = binary.LittleEndian.Uint32(b[0:4])
+	return nil
+}
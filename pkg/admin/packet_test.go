@@ -0,0 +1,174 @@
+package admin
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestPacketAdminJoinBytes checks the encoding of an outbound JOIN packet
+// against a hand-built fixture matching the wire format byte-for-byte.
+func TestPacketAdminJoinBytes(t *testing.T) {
+	pkt := PacketAdminJoin{Password: "hunter2", ClientName: "bot", ClientVersion: "1.0"}
+	got := pkt.Bytes()
+	want := []byte{0x13, 0x00, adminPacketAdminJOIN}
+	want = append(want, []byte("hunter2")...)
+	want = append(want, 0x0)
+	want = append(want, []byte("bot")...)
+	want = append(want, 0x0)
+	want = append(want, []byte("1.0")...)
+	want = append(want, 0x0)
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Bytes() = %v, want %v", got, want)
+	}
+}
+
+// TestPacketAdminUpdateFrequencyBytes checks the fixed-size UPDATE_FREQUENCY encoding.
+func TestPacketAdminUpdateFrequencyBytes(t *testing.T) {
+	pkt := PacketAdminUpdateFrequency{Update: adminUpdateDATE, Frequency: adminFrequencyDAILY}
+	got := pkt.Bytes()
+	want := []byte{0x07, 0x00, adminPacketAdminUPDATE_FREQUENCY, 0x00, 0x00, 0x02, 0x00}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("Bytes() = %v, want %v", got, want)
+	}
+}
+
+// TestPacketServerWelcomeRead decodes a hand-built WELCOME packet fixture.
+func TestPacketServerWelcomeRead(t *testing.T) {
+	payload := []byte("Test Server\x00")
+	payload = append(payload, []byte("12.2\x00")...)
+	payload = append(payload, 0x1) // dedicated
+	payload = append(payload, []byte("Unnamed\x00")...)
+	payload = append(payload, 0x01, 0x02, 0x03, 0x04) // seed
+	payload = append(payload, 0x01)                   // landscape
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00)
+	payload = append(payload, 0xFF, 0x01) // x = 511
+	payload = append(payload, 0xFF, 0x01) // y = 511
+
+	var pkt PacketServerWelcome
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.ServerName != "Test Server" || pkt.ServerVersion != "12.2" || !pkt.Dedicated {
+		t.Fatalf("unexpected header fields: %+v", pkt)
+	}
+	if pkt.MapName != "Unnamed" || pkt.MapSeed != 0x04030201 || pkt.MapLandscape != 1 {
+		t.Fatalf("unexpected map fields: %+v", pkt)
+	}
+	if pkt.MapX != 511 || pkt.MapY != 511 {
+		t.Fatalf("unexpected map size: %dx%d", pkt.MapX, pkt.MapY)
+	}
+}
+
+// TestPacketServerChatRead decodes a hand-built CHAT packet fixture.
+func TestPacketServerChatRead(t *testing.T) {
+	payload := []byte{0x03, 0x00}
+	payload = append(payload, 0x01, 0x00, 0x00, 0x00) // client id 1
+	payload = append(payload, []byte("hi there\x00")...)
+	payload = append(payload, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0) // trailing data
+
+	var pkt PacketServerChat
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.Action != 3 || pkt.ClientID != 1 || pkt.Message != "hi there" {
+		t.Fatalf("unexpected chat fields: %+v", pkt)
+	}
+}
+
+// TestPacketServerChatRead_RejectsTruncatedTrailingData ensures a payload
+// with an empty message and one byte short of the trailing Data field is
+// rejected, rather than silently reading Data from bytes that overlap the
+// message's own terminator.
+func TestPacketServerChatRead_RejectsTruncatedTrailingData(t *testing.T) {
+	payload := []byte{0x03, 0x00}
+	payload = append(payload, 0x01, 0x00, 0x00, 0x00) // client id 1
+	payload = append(payload, 0x0)                    // empty message
+	payload = append(payload, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0)
+
+	var pkt PacketServerChat
+	if err := pkt.Read(payload); err == nil {
+		t.Fatalf("expected an error for a truncated Data field, got pkt=%+v", pkt)
+	}
+}
+
+// TestPacketServerRconRead decodes a hand-built RCON reply fixture.
+func TestPacketServerRconRead(t *testing.T) {
+	payload := []byte{0x0A, 0x00}
+	payload = append(payload, []byte("some output\x00")...)
+
+	var pkt PacketServerRcon
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.Colour != 10 || pkt.Message != "some output" {
+		t.Fatalf("unexpected rcon fields: %+v", pkt)
+	}
+}
+
+// TestPacketServerRead_ShortPacketReturnsError ensures truncated payloads are
+// rejected rather than panicking with an index-out-of-range.
+func TestPacketServerRead_ShortPacketReturnsError(t *testing.T) {
+	var pkt PacketServerDate
+	if err := pkt.Read([]byte{0x01}); err == nil {
+		t.Fatal("expected an error for a truncated DATE packet")
+	}
+}
+
+// TestPacketServerCmdNamesRead decodes a synthetic CMD_NAMES fixture
+// containing two entries.
+func TestPacketServerCmdNamesRead(t *testing.T) {
+	payload := []byte{0x00, 0x00}
+	payload = append(payload, []byte("CMD_BUILD_RAILROAD_TRACK\x00")...)
+	payload = append(payload, 0x01, 0x00)
+	payload = append(payload, []byte("CMD_BUILD_ROAD\x00")...)
+
+	var pkt PacketServerCmdNames
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.Names[0] != "CMD_BUILD_RAILROAD_TRACK" || pkt.Names[1] != "CMD_BUILD_ROAD" {
+		t.Fatalf("unexpected names: %+v", pkt.Names)
+	}
+}
+
+// TestPacketServerCmdLoggingRead decodes a synthetic CMD_LOGGING fixture.
+func TestPacketServerCmdLoggingRead(t *testing.T) {
+	payload := []byte{0x01, 0x00, 0x00, 0x00} // client id 1
+	payload = append(payload, 0x02)           // company id
+	payload = append(payload, 0x2A, 0x00)     // command id 42
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00) // p1
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00) // p2
+	payload = append(payload, 0x00, 0x00, 0x00, 0x00) // tile
+	payload = append(payload, []byte("text\x00")...)
+	payload = append(payload, 0x10, 0x00, 0x00, 0x00) // frame 16
+
+	var pkt PacketServerCmdLogging
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.ClientID != 1 || pkt.CompanyID != 2 || pkt.CommandID != 42 || pkt.Text != "text" || pkt.Frame != 16 {
+		t.Fatalf("unexpected cmd logging fields: %+v", pkt)
+	}
+}
+
+// TestPacketServerCompanyEconomyRead decodes the numeric company economy
+// fields from a synthetic fixture.
+func TestPacketServerCompanyEconomyRead(t *testing.T) {
+	payload := make([]byte, 27)
+	payload[0] = 2 // company id
+	// money = -100
+	money := uint64(0xFFFFFFFFFFFFFF9C)
+	for i := 0; i < 8; i++ {
+		payload[1+i] = byte(money >> (8 * i))
+	}
+	payload[9] = 0x64 // current loan = 100
+
+	var pkt PacketServerCompanyEconomy
+	if err := pkt.Read(payload); err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if pkt.CompanyID != 2 || pkt.Money != -100 || pkt.CurrentLoan != 100 {
+		t.Fatalf("unexpected economy fields: %+v", pkt)
+	}
+}
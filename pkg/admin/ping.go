@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrPingTimeout is returned by Ping when no matching PONG arrives before
+// the ping interval elapses.
+var ErrPingTimeout = errors.New("admin: ping timed out")
+
+// ensurePongDispatch registers the single OnPong handler that routes
+// incoming PONGs to whichever Ping call is waiting on the matching token.
+func (server *OpenTTDServer) ensurePongDispatch() {
+	server.pingOnce.Do(func() {
+		server.pendingMu.Lock()
+		server.pending = make(map[uint32]chan time.Duration)
+		server.pendingMu.Unlock()
+
+		server.OnPong(func(evt PongEvent) {
+			server.pendingMu.Lock()
+			ch, ok := server.pending[evt.Payload]
+			server.pendingMu.Unlock()
+			if ok {
+				select {
+				case ch <- 0:
+				default:
+				}
+			}
+		})
+	})
+}
+
+// Ping sends a PING with a fresh token and blocks until the matching PONG
+// arrives, returning the round-trip time. It returns ErrPingTimeout if no
+// reply arrives within PingInterval (or defaultPingInterval if unset).
+// Concurrent calls are safe - each is tracked by its own token in a
+// pending-ping table guarded by a mutex.
+func (server *OpenTTDServer) Ping() (time.Duration, error) {
+	server.ensurePongDispatch()
+
+	interval := server.PingInterval
+	if interval <= 0 {
+		interval = defaultPingInterval
+	}
+
+	server.pendingMu.Lock()
+	server.pingToken++
+	token := server.pingToken
+	ch := make(chan time.Duration, 1)
+	server.pending[token] = ch
+	server.pendingMu.Unlock()
+
+	defer func() {
+		server.pendingMu.Lock()
+		delete(server.pending, token)
+		server.pendingMu.Unlock()
+	}()
+
+	sentAt := time.Now()
+	server.SendPing(token)
+
+	select {
+	case <-ch:
+		return time.Since(sentAt), nil
+	case <-time.After(interval):
+		return 0, ErrPingTimeout
+	}
+}
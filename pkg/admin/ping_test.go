@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingReturnsRTTOnMatchingPong(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn, PingInterval: time.Second}
+
+	go func() {
+		buf := make([]byte, 1024)
+		n, err := serverConn.Read(buf)
+		if err != nil {
+			return
+		}
+		token := binary.LittleEndian.Uint32(buf[3:n])
+		server.emit("pong", PongEvent{Payload: token})
+	}()
+
+	rtt, err := server.Ping()
+	if err != nil {
+		t.Fatalf("Ping() error: %v", err)
+	}
+	if rtt < 0 {
+		t.Fatalf("unexpected negative rtt: %v", rtt)
+	}
+}
+
+func TestPingTimesOutWithoutPong(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn, PingInterval: 50 * time.Millisecond}
+
+	go func() {
+		buf := make([]byte, 1024)
+		serverConn.Read(buf)
+		// never reply
+	}()
+
+	_, err := server.Ping()
+	if err != ErrPingTimeout {
+		t.Fatalf("Ping() error = %v, want ErrPingTimeout", err)
+	}
+}
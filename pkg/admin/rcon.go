@@ -0,0 +1,79 @@
+package admin
+
+import "context"
+
+// Rcon sends an RCON command and blocks until the server has finished
+// replying, returning the colour of the (first) reply line and every line
+// of output. The admin protocol has no way to correlate an RCON reply
+// with the request that produced it, so concurrent Rcon calls are
+// serialized - only one is ever in flight on the wire at a time.
+func (server *OpenTTDServer) Rcon(ctx context.Context, cmd string) (uint16, []string, error) {
+	server.rconMu.Lock()
+	defer server.rconMu.Unlock()
+
+	events := make(chan interface{}, 64)
+
+	// "rcon" and "rcon_end" are subscribed together so a single dispatch
+	// goroutine delivers both in the order they were emitted - see
+	// subscribeTempMulti.
+	unsub := server.subscribeTempMulti([]string{"rcon", "rcon_end"}, func(evt interface{}) {
+		events <- evt
+	})
+	defer unsub()
+
+	server.rconCommand(cmd)
+
+	var colour uint16
+	var gotColour bool
+	var out []string
+	for {
+		select {
+		case evt := <-events:
+			switch e := evt.(type) {
+			case RconEvent:
+				if !gotColour {
+					colour = e.Colour
+					gotColour = true
+				}
+				out = append(out, e.Message)
+			default:
+				// rcon_end
+				return colour, out, nil
+			}
+		case <-ctx.Done():
+			return colour, out, ctx.Err()
+		}
+	}
+}
+
+// RconStream behaves like Rcon, but invokes handler for each reply line as
+// it arrives instead of collecting them into a slice, for callers that want
+// to process long-running RCON output (e.g. "save") incrementally.
+func (server *OpenTTDServer) RconStream(ctx context.Context, cmd string, handler func(RconEvent)) error {
+	server.rconMu.Lock()
+	defer server.rconMu.Unlock()
+
+	events := make(chan interface{}, 64)
+
+	unsub := server.subscribeTempMulti([]string{"rcon", "rcon_end"}, func(evt interface{}) {
+		events <- evt
+	})
+	defer unsub()
+
+	server.rconCommand(cmd)
+
+	for {
+		select {
+		case evt := <-events:
+			switch e := evt.(type) {
+			case RconEvent:
+				handler(e)
+			default:
+				// rcon_end
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
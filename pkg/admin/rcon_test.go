@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRconCollectsMultipleLines(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn}
+
+	go func() {
+		buf := make([]byte, 1024)
+		// wait for the outgoing RCON command before replying, so the
+		// subscriptions Rcon sets up before writing are guaranteed to
+		// already be in place
+		serverConn.Read(buf)
+		server.emit("rcon", RconEvent{Colour: 5, Message: "line one"})
+		server.emit("rcon", RconEvent{Colour: 5, Message: "line two"})
+		server.emit("rcon_end", "clients")
+	}()
+
+	colour, lines, err := server.Rcon(context.Background(), "clients")
+	if err != nil {
+		t.Fatalf("Rcon() error: %v", err)
+	}
+	if colour != 5 || len(lines) != 2 || lines[0] != "line one" || lines[1] != "line two" {
+		t.Fatalf("unexpected rcon result: colour=%d lines=%v", colour, lines)
+	}
+}
+
+func TestRconStreamInvokesHandlerPerLine(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn}
+
+	go func() {
+		buf := make([]byte, 1024)
+		serverConn.Read(buf)
+		server.emit("rcon", RconEvent{Colour: 1, Message: "a"})
+		server.emit("rcon", RconEvent{Colour: 1, Message: "b"})
+		server.emit("rcon_end", "clients")
+	}()
+
+	var got []string
+	err := server.RconStream(context.Background(), "clients", func(line RconEvent) {
+		got = append(got, line.Message)
+	})
+	if err != nil {
+		t.Fatalf("RconStream() error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("unexpected streamed lines: %v", got)
+	}
+}
+
+func TestRconRespectsContextCancellation(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn}
+
+	go func() {
+		buf := make([]byte, 1024)
+		serverConn.Read(buf)
+		// never reply
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, err := server.Rcon(ctx, "clients")
+	if err == nil {
+		t.Fatal("expected an error when the context is cancelled before RCON_END")
+	}
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultPingInterval is how often the watchdog pings the server when
+	// OpenTTDServer.PingInterval hasn't been set explicitly.
+	defaultPingInterval = 5 * time.Second
+	// maxBackoff caps the reconnect backoff delay.
+	maxBackoff = 60 * time.Second
+	// missedPongLimit is how many consecutive un-ponged pings make the
+	// watchdog force a reconnect.
+	missedPongLimit = 3
+)
+
+// connState tracks whether the admin connection is currently up, and lets
+// callers subscribe to reconnects.
+type connState struct {
+	mu        sync.RWMutex
+	connected bool
+
+	everConnected     bool
+	reconnectHandlers []func()
+
+	// closed is set by Close, and fatal is set when the server reports it
+	// is full, that the admin is banned, or any ERROR (auth failure or
+	// otherwise). Either one tells Connect's loop to stop instead of
+	// reconnecting.
+	closed bool
+	fatal  error
+}
+
+// Close ends the admin session. It closes the underlying connection, which
+// causes Connect's loop to exit instead of reconnecting; Done is closed
+// once that happens.
+func (server *OpenTTDServer) Close() error {
+	server.connState.mu.Lock()
+	server.connState.closed = true
+	server.connState.mu.Unlock()
+
+	if server.connection != nil {
+		return server.connection.Close()
+	}
+	return nil
+}
+
+// Done returns a channel that is closed once Connect's loop has stopped,
+// either because Close was called or because a fatal error (see Err) was
+// encountered.
+func (server *OpenTTDServer) Done() <-chan struct{} {
+	return server.done
+}
+
+// Err returns the fatal error that caused Connect to stop retrying, if any.
+func (server *OpenTTDServer) Err() error {
+	server.connState.mu.RLock()
+	defer server.connState.mu.RUnlock()
+	return server.connState.fatal
+}
+
+func (server *OpenTTDServer) setFatal(err error) {
+	server.connState.mu.Lock()
+	server.connState.fatal = err
+	server.connState.mu.Unlock()
+}
+
+// shouldStop reports whether Connect's loop should stop instead of
+// reconnecting after the current disconnect.
+func (server *OpenTTDServer) shouldStop() bool {
+	server.connState.mu.RLock()
+	defer server.connState.mu.RUnlock()
+	return server.connState.closed || server.connState.fatal != nil
+}
+
+// stop closes the done channel exactly once, regardless of which of
+// Connect's exit points triggers it.
+func (server *OpenTTDServer) stop() {
+	server.doneOnce.Do(func() { close(server.done) })
+}
+
+// Connected reports whether the admin connection is currently established.
+func (server *OpenTTDServer) Connected() bool {
+	server.connState.mu.RLock()
+	defer server.connState.mu.RUnlock()
+	return server.connState.connected
+}
+
+// OnReconnect registers a handler that is called each time the connection
+// is re-established after a disconnect (not on the initial connect).
+func (server *OpenTTDServer) OnReconnect(handler func()) {
+	server.connState.mu.Lock()
+	defer server.connState.mu.Unlock()
+	server.connState.reconnectHandlers = append(server.connState.reconnectHandlers, handler)
+}
+
+func (server *OpenTTDServer) setConnected(connected bool) {
+	server.connState.mu.Lock()
+	wasEverConnected := server.connState.everConnected
+	server.connState.connected = connected
+	if connected {
+		server.connState.everConnected = true
+	}
+	handlers := append([]func(){}, server.connState.reconnectHandlers...)
+	server.connState.mu.Unlock()
+
+	if connected && wasEverConnected {
+		for _, h := range handlers {
+			go h()
+		}
+	}
+}
+
+// nextBackoff doubles d, capped at maxBackoff.
+func nextBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// pingWatchdog sends a PING every PingInterval and forces the connection
+// closed (triggering a reconnect) if missedPongLimit consecutive pings go
+// unanswered - the socket reader otherwise has no way to detect a
+// half-open TCP connection until a write eventually fails.
+func (server *OpenTTDServer) pingWatchdog(done <-chan struct{}) {
+	interval := server.PingInterval
+	if interval <= 0 {
+		interval = defaultPingInterval
+	}
+
+	pongCh := make(chan struct{}, 1)
+	unsub := server.subscribeTempMulti([]string{"pong"}, func(interface{}) {
+		select {
+		case pongCh <- struct{}{}:
+		default:
+		}
+	})
+	defer unsub()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// draw from Ping's token counter so a watchdog PONG can never
+			// be matched to a pending Ping call
+			server.pendingMu.Lock()
+			server.pingToken++
+			token := server.pingToken
+			server.pendingMu.Unlock()
+			server.SendPing(token)
+			select {
+			case <-pongCh:
+				missed = 0
+			case <-time.After(interval):
+				missed++
+				if missed >= missedPongLimit {
+					log.Printf("admin: no PONG after %d attempts, forcing reconnect\n", missed)
+					if server.connection != nil {
+						server.connection.Close()
+					}
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}
+}
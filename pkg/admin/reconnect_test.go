@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPingWatchdogUnsubscribesOnStop(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+
+	server := &OpenTTDServer{connection: clientConn, PingInterval: time.Hour}
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			if _, err := serverConn.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		done := make(chan struct{})
+		watchdogStopped := make(chan struct{})
+		go func() {
+			server.pingWatchdog(done)
+			close(watchdogStopped)
+		}()
+		close(done)
+		<-watchdogStopped
+	}
+
+	server.eventsMu.Lock()
+	got := len(server.subscribers["pong"])
+	server.eventsMu.Unlock()
+
+	if got != 0 {
+		t.Fatalf("pong subscribers after 3 watchdog cycles = %d, want 0", got)
+	}
+}
@@ -0,0 +1,278 @@
+package admin
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client is a snapshot of a connected player, as tracked from the server's
+// CLIENT_* admin packets.
+type Client struct {
+	ID        uint32
+	Name      string
+	Hostname  string
+	Language  uint8
+	JoinDate  uint32
+	CompanyID uint8
+}
+
+// Company is a snapshot of a company, as tracked from the server's
+// COMPANY_* admin packets.
+type Company struct {
+	ID                uint8
+	Name              string
+	ManagerName       string
+	Colour            uint8
+	PasswordProtected bool
+	StartYear         uint32
+	IsAI              bool
+	Money             int64
+	CurrentLoan       uint64
+	Income            int64
+	Delivered         uint16
+	Vehicles          [5]uint16
+	Stations          [5]uint16
+}
+
+// state holds the OpenTTDServer's view of the running game, kept up to date
+// by listenSocket as CLIENT_*/COMPANY_*/DATE packets arrive. All access goes
+// through the methods below so callers on other goroutines see a consistent
+// snapshot.
+type state struct {
+	mu        sync.RWMutex
+	clients   map[uint32]*Client
+	companies map[uint8]*Company
+	gameDate  time.Time
+	paused    bool
+	cmdNames  map[uint16]string
+}
+
+func newState() *state {
+	return &state{
+		clients:   make(map[uint32]*Client),
+		companies: make(map[uint8]*Company),
+		cmdNames:  make(map[uint16]string),
+	}
+}
+
+// ListClients returns a snapshot of all currently known clients.
+func (server *OpenTTDServer) ListClients() []*Client {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	clients := make([]*Client, 0, len(server.state.clients))
+	for _, c := range server.state.clients {
+		copied := *c
+		clients = append(clients, &copied)
+	}
+	return clients
+}
+
+// GetClient returns the client with the given ID, and whether it was found.
+func (server *OpenTTDServer) GetClient(id uint32) (*Client, bool) {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	c, ok := server.state.clients[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *c
+	return &copied, true
+}
+
+// ListCompanies returns a snapshot of all currently known companies.
+func (server *OpenTTDServer) ListCompanies() []*Company {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	companies := make([]*Company, 0, len(server.state.companies))
+	for _, c := range server.state.companies {
+		copied := *c
+		companies = append(companies, &copied)
+	}
+	return companies
+}
+
+// GetCompany returns the company with the given ID, and whether it was found.
+func (server *OpenTTDServer) GetCompany(id uint8) (*Company, bool) {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	c, ok := server.state.companies[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *c
+	return &copied, true
+}
+
+// GameDate returns the most recently reported in-game date.
+func (server *OpenTTDServer) GameDate() time.Time {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	return server.state.gameDate
+}
+
+func (server *OpenTTDServer) trackClientJoin(pkt PacketServerClientJoin) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	if _, ok := server.state.clients[pkt.ClientID]; !ok {
+		server.state.clients[pkt.ClientID] = &Client{ID: pkt.ClientID}
+	}
+}
+
+func (server *OpenTTDServer) trackClientInfo(pkt PacketServerClientInfo) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	server.state.clients[pkt.ClientID] = &Client{
+		ID:        pkt.ClientID,
+		Name:      pkt.Name,
+		Hostname:  pkt.Hostname,
+		Language:  pkt.Language,
+		JoinDate:  pkt.JoinDate,
+		CompanyID: pkt.CompanyID,
+	}
+}
+
+func (server *OpenTTDServer) trackClientUpdate(pkt PacketServerClientUpdate) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	c, ok := server.state.clients[pkt.ClientID]
+	if !ok {
+		c = &Client{ID: pkt.ClientID}
+		server.state.clients[pkt.ClientID] = c
+	}
+	c.Name = pkt.Name
+	c.CompanyID = pkt.CompanyID
+}
+
+func (server *OpenTTDServer) trackClientQuit(pkt PacketServerClientQuit) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	delete(server.state.clients, pkt.ClientID)
+}
+
+func (server *OpenTTDServer) trackClientError(pkt PacketServerClientError) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	delete(server.state.clients, pkt.ClientID)
+}
+
+func (server *OpenTTDServer) trackCompanyNew(pkt PacketServerCompanyNew) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	if _, ok := server.state.companies[pkt.CompanyID]; !ok {
+		server.state.companies[pkt.CompanyID] = &Company{ID: pkt.CompanyID}
+	}
+}
+
+func (server *OpenTTDServer) trackCompanyInfo(pkt PacketServerCompanyInfo) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	c, ok := server.state.companies[pkt.CompanyID]
+	if !ok {
+		c = &Company{ID: pkt.CompanyID}
+		server.state.companies[pkt.CompanyID] = c
+	}
+	c.Name = pkt.Name
+	c.ManagerName = pkt.ManagerName
+	c.Colour = pkt.Colour
+	c.PasswordProtected = pkt.PasswordProtected
+	c.StartYear = pkt.StartYear
+	c.IsAI = pkt.IsAI
+}
+
+func (server *OpenTTDServer) trackCompanyUpdate(pkt PacketServerCompanyUpdate) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	c, ok := server.state.companies[pkt.CompanyID]
+	if !ok {
+		c = &Company{ID: pkt.CompanyID}
+		server.state.companies[pkt.CompanyID] = c
+	}
+	c.Name = pkt.Name
+	c.ManagerName = pkt.ManagerName
+	c.Colour = pkt.Colour
+	c.PasswordProtected = pkt.PasswordProtected
+}
+
+func (server *OpenTTDServer) trackCompanyRemove(pkt PacketServerCompanyRemove) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	delete(server.state.companies, pkt.CompanyID)
+}
+
+func (server *OpenTTDServer) trackCompanyEconomy(pkt PacketServerCompanyEconomy) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	c, ok := server.state.companies[pkt.CompanyID]
+	if !ok {
+		c = &Company{ID: pkt.CompanyID}
+		server.state.companies[pkt.CompanyID] = c
+	}
+	c.Money = pkt.Money
+	c.CurrentLoan = pkt.CurrentLoan
+	c.Income = pkt.Income
+	c.Delivered = pkt.Delivered
+}
+
+func (server *OpenTTDServer) trackCompanyStats(pkt PacketServerCompanyStats) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	c, ok := server.state.companies[pkt.CompanyID]
+	if !ok {
+		c = &Company{ID: pkt.CompanyID}
+		server.state.companies[pkt.CompanyID] = c
+	}
+	c.Vehicles = pkt.Vehicles
+	c.Stations = pkt.Stations
+}
+
+func (server *OpenTTDServer) trackDate(dt time.Time) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	server.state.gameDate = dt
+}
+
+// Paused reports whether the game is currently paused, as last reported on
+// the server's console.
+func (server *OpenTTDServer) Paused() bool {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	return server.state.paused
+}
+
+// CmdNames returns the server's most recently reported mapping of
+// DoCommand IDs to names, as populated by PollCmdNames. The server may
+// split the full list across several PacketServerCmdNames packets, so
+// entries accumulate rather than replace the whole map on each one.
+func (server *OpenTTDServer) CmdNames() map[uint16]string {
+	server.state.mu.RLock()
+	defer server.state.mu.RUnlock()
+	names := make(map[uint16]string, len(server.state.cmdNames))
+	for id, name := range server.state.cmdNames {
+		names[id] = name
+	}
+	return names
+}
+
+func (server *OpenTTDServer) trackCmdNames(pkt PacketServerCmdNames) {
+	server.state.mu.Lock()
+	defer server.state.mu.Unlock()
+	for id, name := range pkt.Names {
+		server.state.cmdNames[id] = name
+	}
+}
+
+// trackConsole watches console output for the pause/unpause announcements
+// OpenTTD prints, since the admin protocol has no dedicated packet for it.
+func (server *OpenTTDServer) trackConsole(pkt PacketServerConsole) {
+	switch {
+	case strings.Contains(pkt.Message, "Game paused"):
+		server.state.mu.Lock()
+		server.state.paused = true
+		server.state.mu.Unlock()
+	case strings.Contains(pkt.Message, "Game unpaused"):
+		server.state.mu.Lock()
+		server.state.paused = false
+		server.state.mu.Unlock()
+	}
+}
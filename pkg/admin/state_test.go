@@ -0,0 +1,62 @@
+package admin
+
+import "testing"
+
+func TestStateTracksClientLifecycle(t *testing.T) {
+	server := &OpenTTDServer{state: newState()}
+
+	server.trackClientJoin(PacketServerClientJoin{ClientID: 5})
+	server.trackClientInfo(PacketServerClientInfo{ClientID: 5, Name: "bob", CompanyID: 1})
+
+	client, ok := server.GetClient(5)
+	if !ok || client.Name != "bob" || client.CompanyID != 1 {
+		t.Fatalf("unexpected client state: %+v (ok=%v)", client, ok)
+	}
+
+	server.trackClientQuit(PacketServerClientQuit{ClientID: 5})
+	if _, ok := server.GetClient(5); ok {
+		t.Fatal("expected client to be removed after quit")
+	}
+}
+
+func TestStateTracksCompanyEconomy(t *testing.T) {
+	server := &OpenTTDServer{state: newState()}
+
+	server.trackCompanyInfo(PacketServerCompanyInfo{CompanyID: 1, Name: "Acme Transport"})
+	server.trackCompanyEconomy(PacketServerCompanyEconomy{CompanyID: 1, Money: 5000, CurrentLoan: 100000})
+
+	company, ok := server.GetCompany(1)
+	if !ok || company.Name != "Acme Transport" || company.Money != 5000 || company.CurrentLoan != 100000 {
+		t.Fatalf("unexpected company state: %+v (ok=%v)", company, ok)
+	}
+
+	if companies := server.ListCompanies(); len(companies) != 1 {
+		t.Fatalf("expected 1 company, got %d", len(companies))
+	}
+}
+
+func TestStateAccumulatesCmdNamesAcrossPackets(t *testing.T) {
+	server := &OpenTTDServer{state: newState()}
+
+	server.trackCmdNames(PacketServerCmdNames{Names: map[uint16]string{1: "CMD_BUILD_RAILROAD_TRACK"}})
+	server.trackCmdNames(PacketServerCmdNames{Names: map[uint16]string{2: "CMD_BUILD_ROAD"}})
+
+	names := server.CmdNames()
+	if names[1] != "CMD_BUILD_RAILROAD_TRACK" || names[2] != "CMD_BUILD_ROAD" {
+		t.Fatalf("unexpected cmd names: %+v", names)
+	}
+}
+
+func TestStateTracksPauseFromConsole(t *testing.T) {
+	server := &OpenTTDServer{state: newState()}
+
+	server.trackConsole(PacketServerConsole{Message: "Game paused (manual)"})
+	if !server.Paused() {
+		t.Fatal("expected server to be paused")
+	}
+
+	server.trackConsole(PacketServerConsole{Message: "Game unpaused (manual)"})
+	if server.Paused() {
+		t.Fatal("expected server to be unpaused")
+	}
+}
@@ -0,0 +1,17 @@
+// Package bridge relays OpenTTD in-game chat to and from external chat
+// platforms (Telegram, a generic webhook, etc). Callers forward in-game
+// chat to Send, and read messages originating on the external platform
+// from Incoming.
+package bridge
+
+// Bridge is implemented by each supported external chat platform.
+type Bridge interface {
+	// Send delivers a message that originated in-game to the external
+	// platform.
+	Send(msg string) error
+	// Incoming returns the channel that messages arriving from the
+	// external platform are delivered on.
+	Incoming() <-chan string
+	// Close shuts the bridge down, releasing any background goroutines.
+	Close() error
+}
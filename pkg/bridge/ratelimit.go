@@ -0,0 +1,23 @@
+package bridge
+
+import "time"
+
+// rateLimiter allows at most one operation per interval, blocking callers
+// until the next tick is available. It exists so a flaky or chatty
+// external platform can't flood the bridge (or get us rate-limited by the
+// platform itself).
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{ticker: time.NewTicker(interval)}
+}
+
+func (r *rateLimiter) wait() {
+	<-r.ticker.C
+}
+
+func (r *rateLimiter) stop() {
+	r.ticker.Stop()
+}
@@ -0,0 +1,168 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const telegramAPIBase = "https://api.telegram.org/bot"
+
+// TelegramBridge relays chat to/from a Telegram chat using long polling,
+// in the same style as the gottdad reference bot.
+type TelegramBridge struct {
+	token  string
+	chatID string
+
+	client   *http.Client
+	incoming chan string
+	done     chan struct{}
+	limiter  *rateLimiter
+	offset   int
+
+	// apiBase is telegramAPIBase, overridden by tests to point at a local
+	// server instead of the real Telegram API.
+	apiBase string
+}
+
+// NewTelegramBridge starts long-polling Telegram for updates addressed to
+// chatID, authenticating with the given bot token.
+func NewTelegramBridge(token string, chatID string) *TelegramBridge {
+	b := &TelegramBridge{
+		token:    token,
+		chatID:   chatID,
+		client:   &http.Client{Timeout: 35 * time.Second},
+		incoming: make(chan string, 32),
+		done:     make(chan struct{}),
+		limiter:  newRateLimiter(time.Second),
+		apiBase:  telegramAPIBase,
+	}
+	go b.poll()
+	return b
+}
+
+func (b *TelegramBridge) apiURL(method string) string {
+	return b.apiBase + b.token + "/" + method
+}
+
+// Send posts msg to the configured Telegram chat.
+func (b *TelegramBridge) Send(msg string) error {
+	b.limiter.wait()
+	form := url.Values{}
+	form.Set("chat_id", b.chatID)
+	form.Set("text", msg)
+	resp, err := b.client.PostForm(b.apiURL("sendMessage"), form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bridge: telegram sendMessage failed: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// Incoming returns the channel messages received from Telegram are
+// delivered on.
+func (b *TelegramBridge) Incoming() <-chan string {
+	return b.incoming
+}
+
+// Close stops the long-poll loop.
+func (b *TelegramBridge) Close() error {
+	close(b.done)
+	b.limiter.stop()
+	return nil
+}
+
+// poll long-polls getUpdates, reconnecting with backoff if Telegram (or
+// the network) misbehaves, so a flaky connection doesn't take the bridge
+// down permanently.
+func (b *TelegramBridge) poll() {
+	backoff := time.Second
+	for {
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+
+		updates, err := b.getUpdates()
+		if err != nil {
+			log.Printf("bridge: telegram getUpdates error: %v, retrying in %v\n", err, backoff)
+			select {
+			case <-time.After(backoff):
+			case <-b.done:
+				return
+			}
+			if backoff < 30*time.Second {
+				backoff *= 2
+			}
+			continue
+		}
+		backoff = time.Second
+
+		for _, u := range updates {
+			b.offset = u.UpdateID + 1
+			if u.Message == nil || u.Message.Text == "" {
+				continue
+			}
+			if strconv.FormatInt(u.Message.Chat.ID, 10) != b.chatID {
+				continue
+			}
+			select {
+			case b.incoming <- u.Message.Text:
+			default:
+				log.Println("bridge: telegram incoming channel full, dropping message")
+			}
+		}
+	}
+}
+
+type telegramUpdate struct {
+	UpdateID int              `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	Text string       `json:"text"`
+	Chat telegramChat `json:"chat"`
+}
+
+type telegramChat struct {
+	ID int64 `json:"id"`
+}
+
+type telegramGetUpdatesResponse struct {
+	OK     bool             `json:"ok"`
+	Result []telegramUpdate `json:"result"`
+}
+
+func (b *TelegramBridge) getUpdates() ([]telegramUpdate, error) {
+	form := url.Values{}
+	form.Set("offset", strconv.Itoa(b.offset))
+	form.Set("timeout", "30")
+	resp, err := b.client.PostForm(b.apiURL("getUpdates"), form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("bridge: telegram getUpdates failed: %s: %s", resp.Status, body)
+	}
+	var parsed telegramGetUpdatesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, err
+	}
+	if !parsed.OK {
+		return nil, fmt.Errorf("bridge: telegram getUpdates returned ok=false")
+	}
+	return parsed.Result, nil
+}
@@ -0,0 +1,53 @@
+package bridge
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTelegramBridgeDropsMessagesFromOtherChats(t *testing.T) {
+	sent := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if sent {
+			fmt.Fprint(w, `{"ok":true,"result":[]}`)
+			return
+		}
+		sent = true
+		fmt.Fprint(w, `{"ok":true,"result":[
+			{"update_id":1,"message":{"text":"from the configured chat","chat":{"id":42}}},
+			{"update_id":2,"message":{"text":"from a stray chat","chat":{"id":99}}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	b := &TelegramBridge{
+		token:    "tok",
+		chatID:   "42",
+		client:   srv.Client(),
+		incoming: make(chan string, 32),
+		done:     make(chan struct{}),
+		limiter:  newRateLimiter(time.Millisecond),
+		apiBase:  srv.URL + "/",
+	}
+	go b.poll()
+	defer b.Close()
+
+	select {
+	case msg := <-b.incoming:
+		if msg != "from the configured chat" {
+			t.Fatalf("got message %q, want %q", msg, "from the configured chat")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the configured chat's message")
+	}
+
+	select {
+	case msg := <-b.incoming:
+		t.Fatalf("got unexpected message from a non-configured chat: %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
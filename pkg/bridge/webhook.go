@@ -0,0 +1,65 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookBridge forwards in-game chat to a generic HTTP webhook (an IRC
+// relay, a Discord/Slack incoming webhook, a custom integration, etc) as a
+// JSON POST body. It does not receive messages back - a WebhookBridge is
+// outbound only.
+type WebhookBridge struct {
+	url     string
+	client  *http.Client
+	limiter *rateLimiter
+}
+
+type webhookPayload struct {
+	Message string `json:"message"`
+}
+
+// NewWebhookBridge posts messages to url as they arrive.
+func NewWebhookBridge(url string) *WebhookBridge {
+	return &WebhookBridge{
+		url:     url,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		limiter: newRateLimiter(200 * time.Millisecond),
+	}
+}
+
+// Send posts msg to the configured webhook URL.
+func (b *WebhookBridge) Send(msg string) error {
+	b.limiter.wait()
+	body, err := json.Marshal(webhookPayload{Message: msg})
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(b.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		errBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("bridge: webhook post failed: %s: %s", resp.Status, errBody)
+	}
+	return nil
+}
+
+// Incoming always returns an empty, never-closed channel - a webhook
+// bridge is outbound only.
+func (b *WebhookBridge) Incoming() <-chan string {
+	return make(chan string)
+}
+
+// Close stops the bridge's rate limiter; WebhookBridge has no background
+// goroutines to stop.
+func (b *WebhookBridge) Close() error {
+	b.limiter.stop()
+	return nil
+}
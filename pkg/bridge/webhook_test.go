@@ -0,0 +1,43 @@
+package bridge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookBridgeSend(t *testing.T) {
+	var got webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	b := NewWebhookBridge(srv.URL)
+	defer b.Close()
+
+	if err := b.Send("hello from the game"); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if got.Message != "hello from the game" {
+		t.Fatalf("got message %q, want %q", got.Message, "hello from the game")
+	}
+}
+
+func TestWebhookBridgeSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	b := NewWebhookBridge(srv.URL)
+	defer b.Close()
+
+	if err := b.Send("hello"); err == nil {
+		t.Fatal("expected an error for a non-2xx response")
+	}
+}